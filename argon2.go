@@ -16,38 +16,39 @@ limitations under the License.
 
 package argon2go
 
-// #cgo CFLAGS: -I/usr/include
-// #cgo LDFLAGS: -L/usr/lib -largon2
-// #include <stdlib.h>
-// #include <argon2.h>
-import "C"
-
 import (
-	"bytes"
-	"crypto/rand"
 	"errors"
 	"strings"
-	"unsafe"
 )
 
 const (
 	// Argon2ModeD is a constant value flag represents argon2d mode in argon2 algorithm.
-	Argon2ModeD int = C.Argon2_d
+	Argon2ModeD int = 0
 
 	// Argon2ModeI is a constant value flag represents argon2i mode in argon2 algorithm.
-	Argon2ModeI int = C.Argon2_i
+	Argon2ModeI int = 1
 
 	// Argon2ModeID is a constant value flag represents argon2id mode in argon2 algorithm.
-	Argon2ModeID int = C.Argon2_id
+	Argon2ModeID int = 2
 
 	// Argon2Version10 is a constant value flag represents argon2 implementation version 10.
-	Argon2Version10 int = C.ARGON2_VERSION_10
+	Argon2Version10 int = 0x10
 
 	// Argon2Version13 is a constant value flag represents argon2 implementation version 13.
-	Argon2Version13 int = C.ARGON2_VERSION_13
+	Argon2Version13 int = 0x13
 
 	// Argon2VersionDefault is a constant value flag represents argon2 implementation default version.
-	Argon2VersionDefault int = C.ARGON2_VERSION_NUMBER
+	Argon2VersionDefault int = Argon2Version13
+)
+
+const (
+	// BackendCGo selects the Hasher implementation bound via cgo to the
+	// reference libargon2 C library.
+	BackendCGo int = iota
+
+	// BackendPureGo selects the build-tag-free, cgo-free Hasher
+	// implementation backed by golang.org/x/crypto/argon2.
+	BackendPureGo
 )
 
 var (
@@ -57,6 +58,10 @@ var (
 	// ErrEmptyHash returned if the hash parameter is nil or empty on calling Verify.
 	ErrEmptyHash = errors.New("empty hash specified")
 
+	// ErrEmptySalt returned if the salt parameter is nil or empty on
+	// calling EncodeRaw or VerifyRaw.
+	ErrEmptySalt = errors.New("empty salt specified")
+
 	// ErrNotConfigured returned on calling Encode or Verify
 	// if the Argon2 Hasher instance is not configured.
 	ErrNotConfigured = errors.New("instance is not configured properly")
@@ -64,6 +69,29 @@ var (
 	// ErrInvalidArgon2Mode returned on calling Verify if the Argon2 mode
 	// extracted from the specified hash is invalid.
 	ErrInvalidArgon2Mode = errors.New("invalid argon2 mode")
+
+	// ErrInvalidEncoding returned if a stored hash does not follow the
+	// expected PHC encoded string format.
+	ErrInvalidEncoding = errors.New("invalid argon2 encoded hash")
+
+	// ErrUnsupportedMode returned by the pure Go backend if the configured
+	// or encoded mode is not implemented by golang.org/x/crypto/argon2.
+	ErrUnsupportedMode = errors.New("argon2 mode not supported by this backend")
+
+	// ErrUnsupportedVersion returned by the pure Go backend if the
+	// configured or encoded version is not implemented by
+	// golang.org/x/crypto/argon2.
+	ErrUnsupportedVersion = errors.New("argon2 version not supported by this backend")
+
+	// ErrUnknownScheme returned by Context.Verify and
+	// Context.VerifyAndUpgrade if the stored hash does not carry a
+	// scheme recognized by any Hasher registered on the Context.
+	ErrUnknownScheme = errors.New("unknown password scheme")
+
+	// ErrSecretUnsupported returned on calling Encode or Verify on the pure
+	// Go backend if a Secret or AssociatedData has been configured, since
+	// golang.org/x/crypto/argon2 does not expose the Argon2 K/X parameters.
+	ErrSecretUnsupported = errors.New("secret and associated data not supported by this backend")
 )
 
 // Argon2Config is a configuration struct
@@ -101,6 +129,22 @@ type Argon2Config struct {
 	// Argon2Version13
 	// Argon2VersionDefault
 	Version int
+
+	// Backend selects which Hasher implementation computes and verifies
+	// the hash. Possible values:
+	// BackendCGo
+	// BackendPureGo
+	Backend int
+
+	// Secret is the optional Argon2 K parameter, a server-side pepper
+	// mixed into the hash but never embedded in the encoded string.
+	// A caller verifying a hash created with a Secret must configure
+	// the same Secret on the verifying Hasher.
+	Secret []byte
+
+	// AssociatedData is the optional Argon2 X parameter, additional data
+	// mixed into the hash but never embedded in the encoded string.
+	AssociatedData []byte
 }
 
 type argon2Hasher struct {
@@ -175,9 +219,48 @@ func Argon2Version(version int) Argon2Option {
 	}
 }
 
+// Argon2Backend returns an Argon2Option that
+// configures a provided reference of Argon2Config
+// with the specified backend.
+//
+// Requesting BackendCGo has no effect on a build compiled without cgo:
+// CreateArgon2 falls back to BackendPureGo regardless, which does not
+// implement every Mode and Version; see CreateArgon2.
+func Argon2Backend(backend int) Argon2Option {
+	return func(conf *Argon2Config) {
+		conf.Backend = backend
+	}
+}
+
+// Argon2Secret returns an Argon2Option that
+// configures a provided reference of Argon2Config
+// with the specified secret (the Argon2 K parameter).
+func Argon2Secret(secret []byte) Argon2Option {
+	return func(conf *Argon2Config) {
+		conf.Secret = secret
+	}
+}
+
+// Argon2AssociatedData returns an Argon2Option that
+// configures a provided reference of Argon2Config
+// with the specified associated data (the Argon2 X parameter).
+func Argon2AssociatedData(ad []byte) Argon2Option {
+	return func(conf *Argon2Config) {
+		conf.AssociatedData = ad
+	}
+}
+
 // CreateArgon2 returns a reference to a Hasher implementation
 // that uses Argon2 algorithm to hash and verify a secret.
 // For more information on Argon2 refer to: https://github.com/P-H-C/phc-winner-argon2
+//
+// Whenever this build was compiled without cgo, the returned Hasher is
+// always the pure Go backend, regardless of the configured Backend; see
+// Argon2Backend. That backend only implements argon2i, argon2id and
+// Argon2Version13 (see pureGoHasher), so a Mode or Version outside of
+// those, set via Argon2Mode or Argon2Version, will not fail here but only
+// later, on the first Encode or Verify call, with ErrUnsupportedMode or
+// ErrUnsupportedVersion.
 func CreateArgon2(options ...Argon2Option) Hasher {
 	conf := &Argon2Config{
 		Iterations:  8,
@@ -187,111 +270,28 @@ func CreateArgon2(options ...Argon2Option) Hasher {
 		SaltLength:  64,
 		Mode:        Argon2ModeID,
 		Version:     Argon2Version13,
+		Backend:     defaultBackend(),
 	}
 
 	for _, opt := range options {
 		opt(conf)
 	}
 
-	return &argon2Hasher{conf: conf}
-}
-
-func (h *argon2Hasher) Encode(raw []byte) ([]byte, error) {
-
-	// Validating configuration.
-	if h.conf == nil {
-		return nil, ErrNotConfigured
-	}
-
-	// Validating input.
-	if raw == nil || len(raw) == 0 {
-		return nil, ErrEmptyInput
-	}
-
-	var err error
-	var c = h.conf
-
-	// Validating salt settings.
-	if c.SaltLength == 0 {
-		return nil, ErrNotConfigured
-	}
-
-	// Generating salt.
-	var salt = make([]byte, c.SaltLength)
-
-	if _, err = rand.Read(salt); err != nil {
-		return nil, err
-	}
-
-	// Determine the ecoded string length.
-	encodedlength := C.argon2_encodedlen(
-		C.uint32_t(c.Iterations),
-		C.uint32_t(c.Memory),
-		C.uint32_t(c.Parallelism),
-		C.uint32_t(len(salt)),
-		C.uint32_t(c.HashLength),
-		C.argon2_type(c.Mode))
-
-	// Creating the buffer for the hash to be stored.
-	hash := make([]byte, encodedlength)
-
-	// Now calling the hash function.
-	result := C.argon2_hash(
-		C.uint32_t(c.Iterations),
-		C.uint32_t(c.Memory),
-		C.uint32_t(c.Parallelism),
-		unsafe.Pointer(&raw[0]), C.size_t(len(raw)),
-		unsafe.Pointer(&salt[0]), C.size_t(len(salt)),
-		nil, C.size_t(c.HashLength),
-		(*C.char)(unsafe.Pointer(&hash[0])), C.size_t(encodedlength),
-		C.argon2_type(c.Mode),
-		C.uint32_t(c.Version))
-
-	if result != C.ARGON2_OK {
-		return nil, errors.New(C.GoString(C.argon2_error_message(C.int(result))))
+	if conf.Backend == BackendPureGo || !cgoAvailable {
+		return &pureGoHasher{conf: conf}
 	}
 
-	return bytes.TrimRight(hash, "\x00"), nil
+	return newCGoHasher(conf)
 }
 
-func (h *argon2Hasher) Verify(raw, hash []byte) (bool, error) {
-
-	if h.conf == nil {
-		return false, ErrNotConfigured
-	}
-
-	if raw == nil || len(raw) == 0 {
-		return false, ErrEmptyInput
-	}
-
-	if hash == nil || len(hash) == 0 {
-		return false, ErrEmptyHash
-	}
-
-	var err error
-	var mode int
-
-	if mode, err = getArgon2Mode(hash); err != nil {
-		return false, err
-	}
-
-	hashString := string(hash)
-	cHashString := C.CString(hashString)
-	defer C.free(unsafe.Pointer(cHashString))
-
-	result := C.argon2_verify(
-		cHashString,
-		unsafe.Pointer(&raw[0]),
-		C.size_t(len(raw)),
-		C.argon2_type(mode))
-
-	if result == C.ARGON2_OK {
-		return true, nil
-	} else if result == C.ARGON2_VERIFY_MISMATCH {
-		return false, nil
+// defaultBackend picks BackendCGo whenever this build was compiled with
+// cgo enabled, falling back to BackendPureGo otherwise.
+func defaultBackend() int {
+	if cgoAvailable {
+		return BackendCGo
 	}
 
-	return false, errors.New(C.GoString(C.argon2_error_message(C.int(result))))
+	return BackendPureGo
 }
 
 func getArgon2Mode(hash []byte) (int, error) {
@@ -0,0 +1,230 @@
+//go:build cgo
+
+/*
+Copyright 2017 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+// #cgo CFLAGS: -I/usr/include
+// #cgo LDFLAGS: -L/usr/lib -largon2
+// #include <stdlib.h>
+// #include <argon2.h>
+import "C"
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"runtime"
+	"unsafe"
+)
+
+// cgoAvailable reports that this build was compiled with cgo enabled,
+// making the libargon2-backed Hasher implementation available.
+const cgoAvailable = true
+
+// newCGoHasher returns a Hasher implementation bound via cgo to the
+// reference libargon2 C library.
+func newCGoHasher(conf *Argon2Config) Hasher {
+	return &argon2Hasher{conf: conf}
+}
+
+func (h *argon2Hasher) Encode(raw []byte) ([]byte, error) {
+
+	// Validating configuration.
+	if h.conf == nil {
+		return nil, ErrNotConfigured
+	}
+
+	// Validating input.
+	if raw == nil || len(raw) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	var err error
+	var c = h.conf
+
+	// Validating salt settings.
+	if c.SaltLength == 0 {
+		return nil, ErrNotConfigured
+	}
+
+	// Generating salt.
+	var salt = make([]byte, c.SaltLength)
+
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	digest := make([]byte, c.HashLength)
+
+	if err = argon2Ctx(c.Mode, c.Version, c.Iterations, c.Memory, c.Parallelism,
+		raw, salt, c.Secret, c.AssociatedData, digest); err != nil {
+		return nil, err
+	}
+
+	return encodePHC(c.Mode, c.Version, c.Memory, c.Iterations, c.Parallelism, salt, digest)
+}
+
+func (h *argon2Hasher) Verify(raw, hash []byte) (bool, error) {
+
+	if h.conf == nil {
+		return false, ErrNotConfigured
+	}
+
+	if raw == nil || len(raw) == 0 {
+		return false, ErrEmptyInput
+	}
+
+	if hash == nil || len(hash) == 0 {
+		return false, ErrEmptyHash
+	}
+
+	mode, version, memory, iterations, parallelism, salt, digest, err := decodePHC(hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	computed := make([]byte, len(digest))
+
+	if err = argon2Ctx(mode, version, iterations, memory, parallelism,
+		raw, salt, h.conf.Secret, h.conf.AssociatedData, computed); err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, digest) == 1, nil
+}
+
+// EncodeRaw computes the raw Argon2 digest of raw under salt, with no PHC
+// encoding and no random salt generation.
+func (h *argon2Hasher) EncodeRaw(raw, salt []byte) ([]byte, error) {
+
+	if h.conf == nil {
+		return nil, ErrNotConfigured
+	}
+
+	if raw == nil || len(raw) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	if salt == nil || len(salt) == 0 {
+		return nil, ErrEmptySalt
+	}
+
+	c := h.conf
+	digest := make([]byte, c.HashLength)
+
+	if err := argon2Ctx(c.Mode, c.Version, c.Iterations, c.Memory, c.Parallelism,
+		raw, salt, c.Secret, c.AssociatedData, digest); err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
+
+// VerifyRaw reports whether digest is the raw Argon2 digest of raw under salt.
+func (h *argon2Hasher) VerifyRaw(raw, salt, digest []byte) (bool, error) {
+
+	if digest == nil || len(digest) == 0 {
+		return false, ErrEmptyHash
+	}
+
+	computed, err := h.EncodeRaw(raw, salt)
+
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, digest) == 1, nil
+}
+
+// NeedsRehash reports whether hash was encoded with a different mode or
+// version, weaker cost parameters, or a shorter salt or digest than h is
+// currently configured with.
+func (h *argon2Hasher) NeedsRehash(hash []byte) (bool, error) {
+
+	if h.conf == nil {
+		return false, ErrNotConfigured
+	}
+
+	return needsRehash(h.conf, hash)
+}
+
+// argon2Ctx computes raw, into digest, the Argon2 hash of raw using the
+// argon2_ctx C API so that an optional secret (K) and associated data (X)
+// can be mixed in; these are not embedded in the PHC encoded string, so a
+// verifying caller must supply the same secret/associated data that was
+// configured when the hash was created.
+func argon2Ctx(mode, version, iterations, memory, parallelism int, raw, salt, secret, ad, digest []byte) error {
+
+	// The context struct below holds several Go pointers at once, which
+	// the cgo pointer-passing rules forbid unless each is pinned. Each
+	// pointer is only taken for non-empty slices, since indexing a zero-
+	// length slice at [0] panics; an empty salt, digest or password is
+	// instead left as a null pointer with a zero length, which libargon2
+	// itself rejects with a proper error (e.g. ARGON2_SALT_TOO_SHORT)
+	// rather than this function crashing on untrusted input.
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+
+	ctx := C.argon2_context{
+		outlen:  C.uint32_t(len(digest)),
+		pwdlen:  C.uint32_t(len(raw)),
+		saltlen: C.uint32_t(len(salt)),
+		t_cost:  C.uint32_t(iterations),
+		m_cost:  C.uint32_t(memory),
+		lanes:   C.uint32_t(parallelism),
+		threads: C.uint32_t(parallelism),
+		version: C.uint32_t(version),
+		flags:   C.ARGON2_DEFAULT_FLAGS,
+	}
+
+	if len(digest) > 0 {
+		pinner.Pin(&digest[0])
+		ctx.out = (*C.uint8_t)(unsafe.Pointer(&digest[0]))
+	}
+
+	if len(raw) > 0 {
+		pinner.Pin(&raw[0])
+		ctx.pwd = (*C.uint8_t)(unsafe.Pointer(&raw[0]))
+	}
+
+	if len(salt) > 0 {
+		pinner.Pin(&salt[0])
+		ctx.salt = (*C.uint8_t)(unsafe.Pointer(&salt[0]))
+	}
+
+	if len(secret) > 0 {
+		pinner.Pin(&secret[0])
+		ctx.secret = (*C.uint8_t)(unsafe.Pointer(&secret[0]))
+		ctx.secretlen = C.uint32_t(len(secret))
+	}
+
+	if len(ad) > 0 {
+		pinner.Pin(&ad[0])
+		ctx.ad = (*C.uint8_t)(unsafe.Pointer(&ad[0]))
+		ctx.adlen = C.uint32_t(len(ad))
+	}
+
+	result := C.argon2_ctx(&ctx, C.argon2_type(mode))
+
+	if result != C.ARGON2_OK {
+		return errors.New(C.GoString(C.argon2_error_message(C.int(result))))
+	}
+
+	return nil
+}
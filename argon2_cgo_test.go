@@ -0,0 +1,239 @@
+//go:build cgo
+
+/*
+Copyright 2017 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type failingReader struct {
+}
+
+func (*failingReader) Read(p []byte) (n int, err error) {
+	return 0, errors.New("failed")
+}
+
+type Argon2TestSuite struct {
+	suite.Suite
+	hasher Hasher
+}
+
+func (suite *Argon2TestSuite) SetupTest() {
+	suite.hasher = CreateArgon2(Argon2HashLength(64))
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_Verify_Success() {
+	encoded, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(suite.T(), err)
+	assert.NotNil(suite.T(), encoded)
+
+	shouldBeTrue, err := suite.hasher.Verify([]byte(CorrectPassPhrase), encoded)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), shouldBeTrue)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_Verify_Mismatch() {
+	encoded, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(suite.T(), err)
+	assert.NotNil(suite.T(), encoded)
+
+	shouldBeFalse, err := suite.hasher.Verify([]byte(IncorrectPassPhrase), []byte(encoded))
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), shouldBeFalse)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_Failure_EmptyInput() {
+	hash, err := suite.hasher.Encode([]byte(""))
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_Failure_NilInput() {
+	hash, err := suite.hasher.Encode(nil)
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_EmptyInput() {
+	hash, err := suite.hasher.Verify([]byte(""), []byte(""))
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_NilInput() {
+	hash, err := suite.hasher.Verify(nil, nil)
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_EmptyHash() {
+	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(""))
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrEmptyHash.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_NilHash() {
+	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), nil)
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrEmptyHash.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_BadMode() {
+	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(BadModeEncoding))
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrInvalidArgon2Mode.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_EmptySaltAndDigest() {
+	shouldBeFalse, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(EmptySaltAndDigestEncoding))
+	assert.False(suite.T(), shouldBeFalse)
+	assert.NotNil(suite.T(), err)
+}
+
+func TestArgon2TestSuite(t *testing.T) {
+	suite.Run(t, new(Argon2TestSuite))
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_NilConf() {
+	suite.hasher.(*argon2Hasher).conf = nil
+	hash, err := suite.hasher.Encode([]byte(""))
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrNotConfigured.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_NilConf() {
+	suite.hasher.(*argon2Hasher).conf = nil
+	verified, err := suite.hasher.Verify([]byte(""), []byte(""))
+	assert.False(suite.T(), verified)
+	assert.EqualError(suite.T(), err, ErrNotConfigured.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_BadSaltConfiguration() {
+	suite.hasher.(*argon2Hasher).conf.SaltLength = 0
+	hash, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, ErrNotConfigured.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_FailedSaltGeneration() {
+	var reader io.Reader
+	rand.Reader, reader = &failingReader{}, rand.Reader
+	hash, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
+	rand.Reader = reader
+	assert.Empty(suite.T(), hash)
+	assert.EqualError(suite.T(), err, "failed")
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_BadMemorySpace() {
+	suite.hasher.(*argon2Hasher).conf.Memory = 0
+	hash, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
+	assert.Empty(suite.T(), hash)
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Verify_BadMemorySpace() {
+	suite.hasher.(*argon2Hasher).conf.Memory = 0
+	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(BadMemoryEncoding))
+	assert.Empty(suite.T(), hash)
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_Encode_Verify_WithSecretAndAssociatedData() {
+	hasher := CreateArgon2(Argon2HashLength(64),
+		Argon2Secret([]byte("pepper")), Argon2AssociatedData([]byte("context")))
+
+	encoded, err := hasher.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(suite.T(), err)
+	assert.NotNil(suite.T(), encoded)
+
+	shouldBeTrue, err := hasher.Verify([]byte(CorrectPassPhrase), encoded)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), shouldBeTrue)
+
+	wrongSecretHasher := CreateArgon2(Argon2HashLength(64),
+		Argon2Secret([]byte("wrong-pepper")), Argon2AssociatedData([]byte("context")))
+
+	shouldBeFalse, err := wrongSecretHasher.Verify([]byte(CorrectPassPhrase), encoded)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), shouldBeFalse)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_NeedsRehash_WeakerIterations() {
+	weakHasher := CreateArgon2(Argon2HashLength(64), Argon2Iterations(1))
+	encoded, err := weakHasher.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(suite.T(), err)
+
+	strongHasher := CreateArgon2(Argon2HashLength(64), Argon2Iterations(16))
+	needsRehash, err := strongHasher.(*argon2Hasher).NeedsRehash(encoded)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), needsRehash)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_NeedsRehash_SameParameters() {
+	encoded, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(suite.T(), err)
+
+	needsRehash, err := suite.hasher.(*argon2Hasher).NeedsRehash(encoded)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), needsRehash)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_NeedsRehash_NilConf() {
+	suite.hasher.(*argon2Hasher).conf = nil
+	needsRehash, err := suite.hasher.(*argon2Hasher).NeedsRehash([]byte(CorrectEncoding))
+	assert.False(suite.T(), needsRehash)
+	assert.EqualError(suite.T(), err, ErrNotConfigured.Error())
+}
+
+func (suite *Argon2TestSuite) TestArgon2_EncodeRaw_VerifyRaw_Success() {
+	salt := []byte("a-caller-supplied-salt-of-any-length")
+	rawHasher := suite.hasher.(*argon2Hasher)
+
+	digest, err := rawHasher.EncodeRaw([]byte(CorrectPassPhrase), salt)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), digest, rawHasher.conf.HashLength)
+
+	shouldBeTrue, err := rawHasher.VerifyRaw([]byte(CorrectPassPhrase), salt, digest)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), shouldBeTrue)
+
+	shouldBeFalse, err := rawHasher.VerifyRaw([]byte(IncorrectPassPhrase), salt, digest)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), shouldBeFalse)
+}
+
+func (suite *Argon2TestSuite) TestArgon2_EncodeRaw_Failure_EmptySalt() {
+	digest, err := suite.hasher.(*argon2Hasher).EncodeRaw([]byte(CorrectPassPhrase), nil)
+	assert.Empty(suite.T(), digest)
+	assert.EqualError(suite.T(), err, ErrEmptySalt.Error())
+}
+
+func TestCreateArgon2_DefaultBackend_CGo(t *testing.T) {
+	hasher := CreateArgon2()
+	_, ok := hasher.(*argon2Hasher)
+	assert.True(t, ok)
+}
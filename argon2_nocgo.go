@@ -0,0 +1,32 @@
+//go:build !cgo
+
+/*
+Copyright 2017 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+// cgoAvailable reports that this build was compiled without cgo, so the
+// libargon2-backed Hasher implementation is unavailable and CreateArgon2
+// falls back to the pure Go backend regardless of the configured Backend;
+// see the CreateArgon2 and Argon2Backend doc comments for the resulting
+// mode/version support gap.
+const cgoAvailable = false
+
+// newCGoHasher is never invoked when cgoAvailable is false; it exists only
+// to satisfy the non-cgo build.
+func newCGoHasher(conf *Argon2Config) Hasher {
+	return nil
+}
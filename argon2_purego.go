@@ -0,0 +1,194 @@
+/*
+Copyright 2018 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// pureGoHasher is a Hasher implementation that computes and verifies
+// Argon2 hashes entirely in Go via golang.org/x/crypto/argon2, requiring
+// neither cgo nor the reference libargon2 C library to be installed.
+//
+// Only the argon2i and argon2id modes and Argon2Version13 are supported,
+// since those are the only mode and version golang.org/x/crypto/argon2
+// implements; argon2d or version 0x10 return ErrUnsupportedMode or
+// ErrUnsupportedVersion respectively.
+type pureGoHasher struct {
+	conf *Argon2Config
+}
+
+func (h *pureGoHasher) Encode(raw []byte) ([]byte, error) {
+
+	// Validating configuration.
+	if h.conf == nil {
+		return nil, ErrNotConfigured
+	}
+
+	// Validating input.
+	if raw == nil || len(raw) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	var err error
+	var c = h.conf
+
+	// Validating salt settings.
+	if c.SaltLength == 0 {
+		return nil, ErrNotConfigured
+	}
+
+	// This backend cannot mix in a secret or associated data.
+	if len(c.Secret) > 0 || len(c.AssociatedData) > 0 {
+		return nil, ErrSecretUnsupported
+	}
+
+	// Generating salt.
+	var salt = make([]byte, c.SaltLength)
+
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	digest, err := pureGoDigest(c.Mode, c.Version, raw, salt,
+		uint32(c.Iterations), uint32(c.Memory), uint8(c.Parallelism), uint32(c.HashLength))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return encodePHC(c.Mode, c.Version, c.Memory, c.Iterations, c.Parallelism, salt, digest)
+}
+
+func (h *pureGoHasher) Verify(raw, hash []byte) (bool, error) {
+
+	if h.conf == nil {
+		return false, ErrNotConfigured
+	}
+
+	if raw == nil || len(raw) == 0 {
+		return false, ErrEmptyInput
+	}
+
+	if hash == nil || len(hash) == 0 {
+		return false, ErrEmptyHash
+	}
+
+	// This backend cannot mix in a secret or associated data.
+	if len(h.conf.Secret) > 0 || len(h.conf.AssociatedData) > 0 {
+		return false, ErrSecretUnsupported
+	}
+
+	mode, version, memory, iterations, parallelism, salt, digest, err := decodePHC(hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	computed, err := pureGoDigest(mode, version, raw, salt,
+		uint32(iterations), uint32(memory), uint8(parallelism), uint32(len(digest)))
+
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, digest) == 1, nil
+}
+
+// EncodeRaw computes the raw Argon2 digest of raw under salt, with no PHC
+// encoding and no random salt generation.
+func (h *pureGoHasher) EncodeRaw(raw, salt []byte) ([]byte, error) {
+
+	if h.conf == nil {
+		return nil, ErrNotConfigured
+	}
+
+	if raw == nil || len(raw) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	if salt == nil || len(salt) == 0 {
+		return nil, ErrEmptySalt
+	}
+
+	c := h.conf
+
+	if len(c.Secret) > 0 || len(c.AssociatedData) > 0 {
+		return nil, ErrSecretUnsupported
+	}
+
+	return pureGoDigest(c.Mode, c.Version, raw, salt,
+		uint32(c.Iterations), uint32(c.Memory), uint8(c.Parallelism), uint32(c.HashLength))
+}
+
+// VerifyRaw reports whether digest is the raw Argon2 digest of raw under salt.
+func (h *pureGoHasher) VerifyRaw(raw, salt, digest []byte) (bool, error) {
+
+	if digest == nil || len(digest) == 0 {
+		return false, ErrEmptyHash
+	}
+
+	computed, err := h.EncodeRaw(raw, salt)
+
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, digest) == 1, nil
+}
+
+// NeedsRehash reports whether hash was encoded with a different mode or
+// version, weaker cost parameters, or a shorter salt or digest than h is
+// currently configured with.
+func (h *pureGoHasher) NeedsRehash(hash []byte) (bool, error) {
+
+	if h.conf == nil {
+		return false, ErrNotConfigured
+	}
+
+	return needsRehash(h.conf, hash)
+}
+
+// pureGoDigest computes the raw Argon2 digest for the given mode and
+// version using golang.org/x/crypto/argon2.
+func pureGoDigest(mode, version int, raw, salt []byte, iterations, memory uint32, parallelism uint8, hashLength uint32) ([]byte, error) {
+	if version != Argon2Version13 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	// golang.org/x/crypto/argon2.Key and IDKey panic with a nil-pointer
+	// dereference when asked for a zero-length key, so a hashLength of 0
+	// (an explicitly configured Argon2HashLength(0), or a decoded digest
+	// from a corrupted/crafted stored hash in Verify) must be rejected
+	// here rather than reaching them; the cgo backend instead gets this
+	// error back from libargon2 itself.
+	if hashLength == 0 {
+		return nil, ErrInvalidEncoding
+	}
+
+	switch mode {
+	case Argon2ModeI:
+		return argon2.Key(raw, salt, iterations, memory, parallelism, hashLength), nil
+	case Argon2ModeID:
+		return argon2.IDKey(raw, salt, iterations, memory, parallelism, hashLength), nil
+	default:
+		return nil, ErrUnsupportedMode
+	}
+}
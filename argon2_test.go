@@ -17,9 +17,6 @@ limitations under the License.
 package argon2go
 
 import (
-	"crypto/rand"
-	"errors"
-	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,25 +34,39 @@ const (
 	IncorrectEncoding = "$argon2i$v=19$m=65536,t=5,p=8$elFNSmhRa2JYdUY4cGc2NXZqUUdoUGJmc1M1VFkxSjh4aWU4cT" +
 		"A0elh5d2k5TVRfN1hqSEVqSDRKT0gteG5OUHkzOEw2OG5zZWdhNFJ6UDVQSTJhc1E9PQ$lo2264d+4pS9yPvTXOZE/sdqc" +
 		"Gz6fFb0o5hqTz1F/2c"
+	EmptySaltAndDigestEncoding = "$argon2id$v=19$m=65536,t=5,p=8$$"
 )
 
-type failingReader struct {
+func TestDecode(t *testing.T) {
+	conf, salt, digest, err := Decode([]byte(CorrectEncoding))
+	assert.Nil(t, err)
+	assert.Equal(t, Argon2ModeID, conf.Mode)
+	assert.Equal(t, Argon2Version13, conf.Version)
+	assert.Equal(t, 65535, conf.Memory)
+	assert.Equal(t, 16, conf.Iterations)
+	assert.Equal(t, 8, conf.Parallelism)
+	assert.NotEmpty(t, salt)
+	assert.NotEmpty(t, digest)
 }
 
-func (*failingReader) Read(p []byte) (n int, err error) {
-	return 0, errors.New("failed")
+func TestDecode_Failure_InvalidMode(t *testing.T) {
+	conf, salt, digest, err := Decode([]byte(BadModeEncoding))
+	assert.Nil(t, conf)
+	assert.Empty(t, salt)
+	assert.Empty(t, digest)
+	assert.EqualError(t, err, ErrInvalidArgon2Mode.Error())
 }
 
-type Argon2TestSuite struct {
+type Argon2PureGoTestSuite struct {
 	suite.Suite
 	hasher Hasher
 }
 
-func (suite *Argon2TestSuite) SetupTest() {
-	suite.hasher = CreateArgon2(Argon2HashLength(64))
+func (suite *Argon2PureGoTestSuite) SetupTest() {
+	suite.hasher = CreateArgon2(Argon2HashLength(64), Argon2Backend(BackendPureGo))
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Encode_Verify_Success() {
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Encode_Verify_Success() {
 	encoded, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), encoded)
@@ -66,105 +77,111 @@ func (suite *Argon2TestSuite) TestArgon2_Encode_Verify_Success() {
 	assert.True(suite.T(), shouldBeTrue)
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Encode_Verify_Mismatch() {
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Encode_Verify_Mismatch() {
 	encoded, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), encoded)
 
-	shouldBeFalse, err := suite.hasher.Verify([]byte(IncorrectPassPhrase), []byte(encoded))
+	shouldBeFalse, err := suite.hasher.Verify([]byte(IncorrectPassPhrase), encoded)
 
 	assert.Nil(suite.T(), err)
 	assert.False(suite.T(), shouldBeFalse)
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Encode_Failure_EmptyInput() {
-	hash, err := suite.hasher.Encode([]byte(""))
-	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
-}
-
-func (suite *Argon2TestSuite) TestArgon2_Encode_Failure_NilInput() {
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Encode_Failure_NilInput() {
 	hash, err := suite.hasher.Encode(nil)
 	assert.Empty(suite.T(), hash)
 	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_EmptyInput() {
-	hash, err := suite.hasher.Verify([]byte(""), []byte(""))
-	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
-}
-
-func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_NilInput() {
-	hash, err := suite.hasher.Verify(nil, nil)
-	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, ErrEmptyInput.Error())
-}
-
-func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_EmptyHash() {
-	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(""))
-	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, ErrEmptyHash.Error())
-}
-
-func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_NilHash() {
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Verify_Failure_NilHash() {
 	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), nil)
 	assert.Empty(suite.T(), hash)
 	assert.EqualError(suite.T(), err, ErrEmptyHash.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Verify_Failure_BadMode() {
-	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(BadModeEncoding))
-	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, ErrInvalidArgon2Mode.Error())
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Verify_Failure_InvalidEncoding() {
+	shouldBeFalse, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte("not-a-phc-hash"))
+	assert.False(suite.T(), shouldBeFalse)
+	assert.EqualError(suite.T(), err, ErrInvalidEncoding.Error())
 }
 
-func TestArgon2TestSuite(t *testing.T) {
-	suite.Run(t, new(Argon2TestSuite))
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Verify_Failure_EmptySaltAndDigest() {
+	shouldBeFalse, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(EmptySaltAndDigestEncoding))
+	assert.False(suite.T(), shouldBeFalse)
+	assert.EqualError(suite.T(), err, ErrInvalidEncoding.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Encode_NilConf() {
-	suite.hasher.(*argon2Hasher).conf = nil
-	hash, err := suite.hasher.Encode([]byte(""))
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Encode_Failure_ZeroHashLength() {
+	suite.hasher.(*pureGoHasher).conf.HashLength = 0
+	hash, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
 	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, ErrNotConfigured.Error())
+	assert.EqualError(suite.T(), err, ErrInvalidEncoding.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Verify_NilConf() {
-	suite.hasher.(*argon2Hasher).conf = nil
-	verified, err := suite.hasher.Verify([]byte(""), []byte(""))
-	assert.False(suite.T(), verified)
-	assert.EqualError(suite.T(), err, ErrNotConfigured.Error())
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_EncodeRaw_Failure_ZeroHashLength() {
+	suite.hasher.(*pureGoHasher).conf.HashLength = 0
+	digest, err := suite.hasher.(*pureGoHasher).EncodeRaw([]byte(CorrectPassPhrase), []byte("some-salt"))
+	assert.Empty(suite.T(), digest)
+	assert.EqualError(suite.T(), err, ErrInvalidEncoding.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Encode_BadSaltConfiguration() {
-	suite.hasher.(*argon2Hasher).conf.SaltLength = 0
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Encode_Failure_UnsupportedMode() {
+	suite.hasher.(*pureGoHasher).conf.Mode = Argon2ModeD
 	hash, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
 	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, ErrNotConfigured.Error())
+	assert.EqualError(suite.T(), err, ErrUnsupportedMode.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Encode_FailedSaltGeneration() {
-	var reader io.Reader
-	rand.Reader, reader = &failingReader{}, rand.Reader
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Encode_Failure_UnsupportedVersion() {
+	suite.hasher.(*pureGoHasher).conf.Version = Argon2Version10
 	hash, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
-	rand.Reader = reader
 	assert.Empty(suite.T(), hash)
-	assert.EqualError(suite.T(), err, "failed")
+	assert.EqualError(suite.T(), err, ErrUnsupportedVersion.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Encode_BadMemorySpace() {
-	suite.hasher.(*argon2Hasher).conf.Memory = 0
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_Encode_Failure_SecretUnsupported() {
+	suite.hasher.(*pureGoHasher).conf.Secret = []byte("pepper")
 	hash, err := suite.hasher.Encode([]byte(CorrectPassPhrase))
 	assert.Empty(suite.T(), hash)
-	assert.NotNil(suite.T(), err)
+	assert.EqualError(suite.T(), err, ErrSecretUnsupported.Error())
 }
 
-func (suite *Argon2TestSuite) TestArgon2_Verify_BadMemorySpace() {
-	suite.hasher.(*argon2Hasher).conf.Memory = 0
-	hash, err := suite.hasher.Verify([]byte(CorrectPassPhrase), []byte(BadMemoryEncoding))
-	assert.Empty(suite.T(), hash)
-	assert.NotNil(suite.T(), err)
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_EncodeRaw_VerifyRaw_Success() {
+	salt := []byte("a-caller-supplied-salt-of-any-length")
+	rawHasher := suite.hasher.(*pureGoHasher)
+
+	digest, err := rawHasher.EncodeRaw([]byte(CorrectPassPhrase), salt)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), digest, rawHasher.conf.HashLength)
+
+	shouldBeTrue, err := rawHasher.VerifyRaw([]byte(CorrectPassPhrase), salt, digest)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), shouldBeTrue)
+}
+
+func (suite *Argon2PureGoTestSuite) TestArgon2PureGo_NeedsRehash_WeakerMemory() {
+	weakHasher := CreateArgon2(Argon2HashLength(64), Argon2Backend(BackendPureGo), Argon2Memory(8))
+	encoded, err := weakHasher.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(suite.T(), err)
+
+	suite.hasher.(*pureGoHasher).conf.Memory = 1 << 16
+	needsRehash, err := suite.hasher.(*pureGoHasher).NeedsRehash(encoded)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), needsRehash)
+}
+
+func TestArgon2PureGoTestSuite(t *testing.T) {
+	suite.Run(t, new(Argon2PureGoTestSuite))
+}
+
+func TestCreateArgon2_DefaultBackend(t *testing.T) {
+	hasher := CreateArgon2()
+
+	if !cgoAvailable {
+		_, ok := hasher.(*pureGoHasher)
+		assert.True(t, ok)
+	}
 }
 
 func TestGetArgon2Mode(t *testing.T) {
@@ -205,6 +222,7 @@ func TestArgon2Options(t *testing.T) {
 		Parallelism: 0,
 		SaltLength:  0,
 		Version:     0,
+		Backend:     0,
 	}
 
 	Argon2HashLength(1)(c)
@@ -213,7 +231,10 @@ func TestArgon2Options(t *testing.T) {
 	Argon2Mode(4)(c)
 	Argon2Parallelism(5)(c)
 	Argon2SaltLength(6)(c)
+	Argon2Backend(8)(c)
 	Argon2Version(7)(c)
+	Argon2Secret([]byte("pepper"))(c)
+	Argon2AssociatedData([]byte("context"))(c)
 
 	assert.Equal(t, 1, c.HashLength)
 	assert.Equal(t, 2, c.Iterations)
@@ -222,6 +243,9 @@ func TestArgon2Options(t *testing.T) {
 	assert.Equal(t, 5, c.Parallelism)
 	assert.Equal(t, 6, c.SaltLength)
 	assert.Equal(t, 7, c.Version)
+	assert.Equal(t, 8, c.Backend)
+	assert.Equal(t, []byte("pepper"), c.Secret)
+	assert.Equal(t, []byte("context"), c.AssociatedData)
 }
 
 func runArgon2EncodeBenchmark(hasher Hasher, b *testing.B) {
@@ -0,0 +1,262 @@
+/*
+Copyright 2018 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+import (
+	"runtime"
+	"sort"
+	"time"
+)
+
+// CalibrateConfig carries the constraints Calibrate benchmarks against
+// while searching for Argon2 cost parameters that hit a target latency.
+type CalibrateConfig struct {
+	// MaxMemoryKB caps how far Calibrate may grow Memory, in kilobytes,
+	// while searching for the target latency.
+	MaxMemoryKB int
+
+	// MaxParallelism caps the number of lanes/threads Calibrate fixes
+	// Parallelism to, defaulting to and never exceeding runtime.NumCPU().
+	MaxParallelism int
+
+	// MinMemoryKB is the lowest Memory, in kilobytes, Calibrate will
+	// ever return, regardless of how fast the local machine is.
+	MinMemoryKB int
+
+	// MinIterations is the lowest Iterations Calibrate will ever
+	// return, regardless of how fast the local machine is.
+	MinIterations int
+
+	// Mode, Version, HashLength, SaltLength and Backend seed the
+	// Argon2Config benchmarked by Calibrate and are carried over as is
+	// into the returned configuration.
+	Mode       int
+	Version    int
+	HashLength int
+	SaltLength int
+	Backend    int
+}
+
+// CalibrateOption is a callback function that is meant
+// to configure a provided reference to CalibrateConfig
+// structure.
+type CalibrateOption func(conf *CalibrateConfig)
+
+// CalibrateMaxMemory returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified memory cap in kilobytes.
+func CalibrateMaxMemory(maxMemoryKB int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.MaxMemoryKB = maxMemoryKB
+	}
+}
+
+// CalibrateMaxParallelism returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified parallelism cap.
+func CalibrateMaxParallelism(maxParallelism int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.MaxParallelism = maxParallelism
+	}
+}
+
+// CalibrateMinMemory returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified memory floor in kilobytes.
+func CalibrateMinMemory(minMemoryKB int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.MinMemoryKB = minMemoryKB
+	}
+}
+
+// CalibrateMinIterations returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified iterations floor.
+func CalibrateMinIterations(minIterations int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.MinIterations = minIterations
+	}
+}
+
+// CalibrateMode returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified mode.
+func CalibrateMode(mode int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.Mode = mode
+	}
+}
+
+// CalibrateVersion returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified version.
+func CalibrateVersion(version int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.Version = version
+	}
+}
+
+// CalibrateHashLength returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified hash length in bytes.
+func CalibrateHashLength(hashLength int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.HashLength = hashLength
+	}
+}
+
+// CalibrateSaltLength returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified salt length in bytes.
+func CalibrateSaltLength(saltLength int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.SaltLength = saltLength
+	}
+}
+
+// CalibrateBackend returns a CalibrateOption that
+// configures a provided reference of CalibrateConfig
+// with the specified backend to benchmark.
+func CalibrateBackend(backend int) CalibrateOption {
+	return func(conf *CalibrateConfig) {
+		conf.Backend = backend
+	}
+}
+
+// Calibrate benchmarks Encode on the local machine and returns an
+// Argon2Config whose Iterations and Memory are tuned so that hashing
+// takes approximately target. Parallelism is fixed upfront to
+// runtime.NumCPU() (capped by CalibrateMaxParallelism); Memory is then
+// grown, at a single iteration, up to the configured cap until a single
+// pass approaches target, after which Iterations is increased linearly
+// for the remainder and a final 3-run median is taken to smooth out
+// noise. MinMemoryKB and MinIterations floor the result so it is never
+// weaker than the configured, OWASP-recommended by default, minimums.
+func Calibrate(target time.Duration, opts ...CalibrateOption) (*Argon2Config, error) {
+	conf := &CalibrateConfig{
+		MaxMemoryKB:    1 << 20,
+		MaxParallelism: runtime.NumCPU(),
+		MinMemoryKB:    19456,
+		MinIterations:  2,
+		Mode:           Argon2ModeID,
+		Version:        Argon2Version13,
+		HashLength:     32,
+		SaltLength:     16,
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	parallelism := conf.MaxParallelism
+
+	if numCPU := runtime.NumCPU(); parallelism <= 0 || parallelism > numCPU {
+		parallelism = numCPU
+	}
+
+	result := &Argon2Config{
+		Iterations:  1,
+		Memory:      conf.MinMemoryKB,
+		Parallelism: parallelism,
+		HashLength:  conf.HashLength,
+		SaltLength:  conf.SaltLength,
+		Mode:        conf.Mode,
+		Version:     conf.Version,
+		Backend:     conf.Backend,
+	}
+
+	// Grow Memory, at a single iteration, until a single pass approaches
+	// target or the memory cap is reached.
+	for result.Memory < conf.MaxMemoryKB {
+		elapsed, err := benchmarkEncode(result)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if elapsed >= target {
+			break
+		}
+
+		if result.Memory *= 2; result.Memory > conf.MaxMemoryKB {
+			result.Memory = conf.MaxMemoryKB
+		}
+	}
+
+	// Increase Iterations linearly for the remainder of the target.
+	elapsed, err := benchmarkEncode(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if elapsed > 0 && elapsed < target {
+		result.Iterations = int(float64(result.Iterations) * float64(target) / float64(elapsed))
+	}
+
+	if result.Iterations < conf.MinIterations {
+		result.Iterations = conf.MinIterations
+	}
+
+	// Final 3-run median to avoid noise.
+	samples := make([]time.Duration, 3)
+
+	for i := range samples {
+		if samples[i], err = benchmarkEncode(result); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	if median := samples[1]; median > 0 && median < target {
+		result.Iterations += int(float64(result.Iterations) * float64(target-median) / float64(median))
+	}
+
+	if result.Iterations < conf.MinIterations {
+		result.Iterations = conf.MinIterations
+	}
+
+	if result.Memory < conf.MinMemoryKB {
+		result.Memory = conf.MinMemoryKB
+	}
+
+	return result, nil
+}
+
+// benchmarkEncode measures how long a single Encode call takes using a
+// Hasher built from conf.
+func benchmarkEncode(conf *Argon2Config) (time.Duration, error) {
+	hasher := CreateArgon2(
+		Argon2Iterations(conf.Iterations),
+		Argon2Memory(conf.Memory),
+		Argon2Parallelism(conf.Parallelism),
+		Argon2HashLength(conf.HashLength),
+		Argon2SaltLength(conf.SaltLength),
+		Argon2Mode(conf.Mode),
+		Argon2Version(conf.Version),
+		Argon2Backend(conf.Backend),
+	)
+
+	start := time.Now()
+
+	if _, err := hasher.Encode([]byte("argon2go-calibration-probe")); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrateOptions(t *testing.T) {
+	c := &CalibrateConfig{}
+
+	CalibrateMaxMemory(1)(c)
+	CalibrateMaxParallelism(2)(c)
+	CalibrateMinMemory(3)(c)
+	CalibrateMinIterations(4)(c)
+	CalibrateMode(5)(c)
+	CalibrateVersion(6)(c)
+	CalibrateHashLength(7)(c)
+	CalibrateSaltLength(8)(c)
+	CalibrateBackend(9)(c)
+
+	assert.Equal(t, 1, c.MaxMemoryKB)
+	assert.Equal(t, 2, c.MaxParallelism)
+	assert.Equal(t, 3, c.MinMemoryKB)
+	assert.Equal(t, 4, c.MinIterations)
+	assert.Equal(t, 5, c.Mode)
+	assert.Equal(t, 6, c.Version)
+	assert.Equal(t, 7, c.HashLength)
+	assert.Equal(t, 8, c.SaltLength)
+	assert.Equal(t, 9, c.Backend)
+}
+
+func TestCalibrate_Success(t *testing.T) {
+	conf, err := Calibrate(20*time.Millisecond,
+		CalibrateMinMemory(8),
+		CalibrateMaxMemory(1<<12),
+		CalibrateMinIterations(1),
+		CalibrateMaxParallelism(1),
+		CalibrateHashLength(16),
+		CalibrateSaltLength(8))
+
+	assert.Nil(t, err)
+	assert.NotNil(t, conf)
+	assert.GreaterOrEqual(t, conf.Memory, 8)
+	assert.GreaterOrEqual(t, conf.Iterations, 1)
+	assert.Equal(t, 1, conf.Parallelism)
+	assert.Equal(t, Argon2ModeID, conf.Mode)
+	assert.Equal(t, Argon2Version13, conf.Version)
+}
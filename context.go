@@ -0,0 +1,152 @@
+/*
+Copyright 2018 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+import "strings"
+
+// Context is a pluggable, passlib-style registry of Hasher implementations
+// keyed by the "$scheme$..." prefix each one encodes its hashes with. The
+// first Hasher registered is the preferred scheme, used by Hash to create
+// new hashes; every registered Hasher, preferred or not, is recognized by
+// Verify and VerifyAndUpgrade, so legacy schemes can still be checked while
+// new hashes move to the preferred one.
+//
+// The zero value Context is ready to use.
+type Context struct {
+	preferred string
+	hashers   map[string]Hasher
+}
+
+// Register adds h to the Context under the given scheme name, the same
+// name that appears as the leading "$scheme$..." segment of the hashes h
+// produces. The first scheme ever registered on a Context becomes its
+// preferred scheme.
+func (c *Context) Register(scheme string, h Hasher) {
+	if c.hashers == nil {
+		c.hashers = make(map[string]Hasher)
+	}
+
+	c.hashers[scheme] = h
+
+	if c.preferred == "" {
+		c.preferred = scheme
+	}
+}
+
+// Hash encodes raw using the preferred Hasher.
+func (c *Context) Hash(raw []byte) ([]byte, error) {
+	if c.preferred == "" {
+		return nil, ErrNotConfigured
+	}
+
+	return c.hashers[c.preferred].Encode(raw)
+}
+
+// Verify checks raw against stored using whichever registered Hasher
+// matches the scheme encoded in stored.
+func (c *Context) Verify(raw, stored []byte) (bool, error) {
+	h, err := c.hasherFor(stored)
+
+	if err != nil {
+		return false, err
+	}
+
+	return h.Verify(raw, stored)
+}
+
+// VerifyAndUpgrade behaves like Verify, additionally returning a freshly
+// encoded hash, using the preferred Hasher, whenever stored was created by
+// a non-preferred scheme or, for the preferred scheme, whenever it
+// implements RehashChecker and reports that stored needs a rehash. The
+// returned hash is nil whenever no upgrade is necessary or raw failed to
+// verify.
+func (c *Context) VerifyAndUpgrade(raw, stored []byte) (bool, []byte, error) {
+	scheme, h, err := c.schemeAndHasherFor(stored)
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	verified, err := h.Verify(raw, stored)
+
+	if err != nil || !verified {
+		return verified, nil, err
+	}
+
+	upgrade := scheme != c.preferred
+
+	if !upgrade {
+		if rc, ok := h.(RehashChecker); ok {
+			if upgrade, err = rc.NeedsRehash(stored); err != nil {
+				return true, nil, err
+			}
+		}
+	}
+
+	if !upgrade {
+		return true, nil, nil
+	}
+
+	newHash, err := c.Hash(raw)
+
+	if err != nil {
+		return true, nil, err
+	}
+
+	return true, newHash, nil
+}
+
+// hasherFor returns the registered Hasher matching the scheme encoded in stored.
+func (c *Context) hasherFor(stored []byte) (Hasher, error) {
+	_, h, err := c.schemeAndHasherFor(stored)
+	return h, err
+}
+
+// schemeAndHasherFor returns the scheme name and registered Hasher matching
+// the "$scheme$..." prefix encoded in stored.
+func (c *Context) schemeAndHasherFor(stored []byte) (string, Hasher, error) {
+	scheme, err := detectScheme(stored)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	h, ok := c.hashers[scheme]
+
+	if !ok {
+		return "", nil, ErrUnknownScheme
+	}
+
+	return scheme, h, nil
+}
+
+// detectScheme extracts the leading "$scheme$..." segment off an encoded hash.
+func detectScheme(hash []byte) (string, error) {
+	s := string(hash)
+
+	if len(s) == 0 || s[0] != '$' {
+		return "", ErrUnknownScheme
+	}
+
+	parts := strings.SplitN(s[1:], "$", 2)
+
+	if len(parts) == 0 || parts[0] == "" {
+		return "", ErrUnknownScheme
+	}
+
+	return parts[0], nil
+}
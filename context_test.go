@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// legacyHasher is a minimal Hasher stub standing in for a legacy scheme
+// (e.g. bcrypt/scrypt/pbkdf2) adapter in the Context tests below; it
+// encodes as "$legacy$<raw>" and only ever verifies against that format.
+type legacyHasher struct{}
+
+func (legacyHasher) Encode(raw []byte) ([]byte, error) {
+	return []byte("$legacy$" + string(raw)), nil
+}
+
+func (legacyHasher) Verify(raw, hash []byte) (bool, error) {
+	return string(hash) == "$legacy$"+string(raw), nil
+}
+
+func newTestContext() *Context {
+	ctx := &Context{}
+	ctx.Register("argon2id", CreateArgon2(Argon2HashLength(16)))
+	ctx.Register("legacy", legacyHasher{})
+	return ctx
+}
+
+func TestContext_Hash_UsesPreferredScheme(t *testing.T) {
+	ctx := newTestContext()
+
+	hash, err := ctx.Hash([]byte(CorrectPassPhrase))
+	assert.Nil(t, err)
+
+	scheme, err := detectScheme(hash)
+	assert.Nil(t, err)
+	assert.Equal(t, "argon2id", scheme)
+}
+
+func TestContext_Hash_Failure_NotConfigured(t *testing.T) {
+	hash, err := (&Context{}).Hash([]byte(CorrectPassPhrase))
+	assert.Empty(t, hash)
+	assert.EqualError(t, err, ErrNotConfigured.Error())
+}
+
+func TestContext_Verify_PreferredAndLegacyScheme(t *testing.T) {
+	ctx := newTestContext()
+
+	preferredHash, err := ctx.Hash([]byte(CorrectPassPhrase))
+	assert.Nil(t, err)
+
+	verified, err := ctx.Verify([]byte(CorrectPassPhrase), preferredHash)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	legacyHash, err := legacyHasher{}.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(t, err)
+
+	verified, err = ctx.Verify([]byte(CorrectPassPhrase), legacyHash)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+}
+
+func TestContext_Verify_Failure_UnknownScheme(t *testing.T) {
+	ctx := newTestContext()
+
+	verified, err := ctx.Verify([]byte(CorrectPassPhrase), []byte("$unknown$whatever"))
+	assert.False(t, verified)
+	assert.EqualError(t, err, ErrUnknownScheme.Error())
+}
+
+func TestContext_VerifyAndUpgrade_UpgradesLegacyScheme(t *testing.T) {
+	ctx := newTestContext()
+
+	legacyHash, err := legacyHasher{}.Encode([]byte(CorrectPassPhrase))
+	assert.Nil(t, err)
+
+	verified, newHash, err := ctx.VerifyAndUpgrade([]byte(CorrectPassPhrase), legacyHash)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	assert.NotEmpty(t, newHash)
+
+	scheme, err := detectScheme(newHash)
+	assert.Nil(t, err)
+	assert.Equal(t, "argon2id", scheme)
+}
+
+func TestContext_VerifyAndUpgrade_NoUpgradeForFreshPreferredHash(t *testing.T) {
+	ctx := newTestContext()
+
+	preferredHash, err := ctx.Hash([]byte(CorrectPassPhrase))
+	assert.Nil(t, err)
+
+	verified, newHash, err := ctx.VerifyAndUpgrade([]byte(CorrectPassPhrase), preferredHash)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	assert.Empty(t, newHash)
+}
+
+func TestContext_VerifyAndUpgrade_UpgradesWeakerPreferredHash(t *testing.T) {
+	ctx := &Context{}
+	ctx.Register("argon2id", CreateArgon2(Argon2HashLength(16), Argon2Iterations(1)))
+
+	staleHash, err := ctx.Hash([]byte(CorrectPassPhrase))
+	assert.Nil(t, err)
+
+	ctx.Register("argon2id", CreateArgon2(Argon2HashLength(16), Argon2Iterations(16)))
+
+	verified, newHash, err := ctx.VerifyAndUpgrade([]byte(CorrectPassPhrase), staleHash)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	assert.NotEmpty(t, newHash)
+}
+
+func TestContext_VerifyAndUpgrade_Failure_Mismatch(t *testing.T) {
+	ctx := newTestContext()
+
+	preferredHash, err := ctx.Hash([]byte(CorrectPassPhrase))
+	assert.Nil(t, err)
+
+	verified, newHash, err := ctx.VerifyAndUpgrade([]byte(IncorrectPassPhrase), preferredHash)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+	assert.Empty(t, newHash)
+}
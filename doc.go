@@ -23,6 +23,14 @@ Brief
 The package offers a simple interface Hasher with argon2 default C binding implementation,
 the implementation implicitly generates salt based on a secure random byte array while encoding.
 
+A pure Go implementation backed by golang.org/x/crypto/argon2 is also available for
+environments where cgo or the libargon2 C library are unavailable, selectable via the
+Argon2Backend option and produces hashes in the same encoded string format. Builds
+compiled without cgo always use this backend, regardless of the configured
+Argon2Backend; it only implements argon2i, argon2id and Argon2Version13, so requesting
+argon2d or Argon2Version10 without cgo available will only fail once Encode or Verify
+is called, with ErrUnsupportedMode or ErrUnsupportedVersion.
+
 Usage
 
 Be sure that you have argon2 development C library is installed correctly along with its header
@@ -31,3 +31,33 @@ type Hasher interface {
 	// It also returns an error on failure to check.
 	Verify([]byte, []byte) (bool, error)
 }
+
+// RawHasher is implemented by Hasher implementations that can also
+// produce or check a raw Argon2 digest for a caller-supplied salt, with
+// no PHC encoding and no random salt generation. This is meant for using
+// Argon2 as a KDF, e.g. deriving symmetric keys, rather than for password
+// storage.
+type RawHasher interface {
+	// EncodeRaw computes the raw Argon2 digest of the first byte array
+	// using the second as salt, returning just the HashLength-byte
+	// digest. It also may return an error with a nil byte array on
+	// failure.
+	EncodeRaw(raw, salt []byte) ([]byte, error)
+
+	// VerifyRaw reports whether the third byte array is the raw Argon2
+	// digest of the first under the second (the salt). It also returns
+	// an error on failure to check.
+	VerifyRaw(raw, salt, digest []byte) (bool, error)
+}
+
+// RehashChecker is implemented by Hasher implementations that can tell
+// whether a previously encoded hash falls short of their current
+// configuration and should therefore be recomputed.
+type RehashChecker interface {
+	// NeedsRehash parses a hash produced by Encode and reports whether it
+	// should be recomputed with the Hasher's current configuration,
+	// because its mode or version differs, its cost parameters are
+	// weaker, or its salt or digest is shorter.
+	// It also returns an error on failure to parse the hash.
+	NeedsRehash(hash []byte) (bool, error)
+}
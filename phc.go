@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Ahmed Zaher
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argon2go
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// phcEncoding is the base64 variant used by the PHC string format: standard
+// alphabet, no padding.
+var phcEncoding = base64.RawStdEncoding
+
+// modeName returns the PHC scheme name for the given mode, e.g. "argon2id".
+func modeName(mode int) (string, error) {
+	switch mode {
+	case Argon2ModeD:
+		return "argon2d", nil
+	case Argon2ModeI:
+		return "argon2i", nil
+	case Argon2ModeID:
+		return "argon2id", nil
+	default:
+		return "", ErrInvalidArgon2Mode
+	}
+}
+
+// parseModeName is the inverse of modeName.
+func parseModeName(name string) (int, error) {
+	switch name {
+	case "argon2d":
+		return Argon2ModeD, nil
+	case "argon2i":
+		return Argon2ModeI, nil
+	case "argon2id":
+		return Argon2ModeID, nil
+	default:
+		return -1, ErrInvalidArgon2Mode
+	}
+}
+
+// encodePHC renders the given mode, cost parameters and raw salt/digest
+// bytes into the PHC string format shared with the reference argon2 C
+// implementation: $argon2{i,d,id}$v=..$m=..,t=..,p=..$<b64salt>$<b64hash>
+func encodePHC(mode, version, memory, iterations, parallelism int, salt, digest []byte) ([]byte, error) {
+	name, err := modeName(mode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		name, version, memory, iterations, parallelism,
+		phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(digest))), nil
+}
+
+// decodePHC parses a PHC formatted argon2 hash as produced by encodePHC,
+// returning its mode, version and cost parameters along with the raw salt
+// and digest bytes.
+func decodePHC(hash []byte) (mode, version, memory, iterations, parallelism int, salt, digest []byte, err error) {
+	// A valid encoded hash has the shape
+	// "$argon2{i,d,id}$v=..$m=..,t=..,p=..$salt$hash", i.e. 6 parts once
+	// split on "$", the first of which is empty.
+	parts := strings.Split(string(hash), "$")
+
+	if len(parts) != 6 || parts[0] != "" {
+		return 0, 0, 0, 0, 0, nil, nil, ErrInvalidEncoding
+	}
+
+	if mode, err = parseModeName(parts[1]); err != nil {
+		return 0, 0, 0, 0, 0, nil, nil, err
+	}
+
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return 0, 0, 0, 0, 0, nil, nil, ErrInvalidEncoding
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return 0, 0, 0, 0, 0, nil, nil, ErrInvalidEncoding
+	}
+
+	if salt, err = phcEncoding.DecodeString(parts[4]); err != nil {
+		return 0, 0, 0, 0, 0, nil, nil, ErrInvalidEncoding
+	}
+
+	if digest, err = phcEncoding.DecodeString(parts[5]); err != nil {
+		return 0, 0, 0, 0, 0, nil, nil, ErrInvalidEncoding
+	}
+
+	return mode, version, memory, iterations, parallelism, salt, digest, nil
+}
+
+// Decode parses a PHC formatted argon2 hash as produced by Encode,
+// returning the Argon2Config it was created with (Secret and
+// AssociatedData are never encoded in the string and so are left unset)
+// along with its raw salt and digest bytes.
+func Decode(hash []byte) (*Argon2Config, []byte, []byte, error) {
+	mode, version, memory, iterations, parallelism, salt, digest, err := decodePHC(hash)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	conf := &Argon2Config{
+		Mode:        mode,
+		Version:     version,
+		Memory:      memory,
+		Iterations:  iterations,
+		Parallelism: parallelism,
+		SaltLength:  len(salt),
+		HashLength:  len(digest),
+	}
+
+	return conf, salt, digest, nil
+}
+
+// needsRehash reports whether hash was encoded with a different mode or
+// version, weaker cost parameters, or a shorter salt or digest than those
+// held by current, and so should be recomputed.
+func needsRehash(current *Argon2Config, hash []byte) (bool, error) {
+	stored, salt, digest, err := Decode(hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	if stored.Mode != current.Mode || stored.Version != current.Version {
+		return true, nil
+	}
+
+	if stored.Iterations < current.Iterations || stored.Memory < current.Memory ||
+		stored.Parallelism < current.Parallelism {
+		return true, nil
+	}
+
+	if len(salt) < current.SaltLength || len(digest) < current.HashLength {
+		return true, nil
+	}
+
+	return false, nil
+}